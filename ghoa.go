@@ -2,13 +2,25 @@ package main
 
 import (
 	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/csv"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"math/rand"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 // User represents the structure of the GitHub user data returned by the API
@@ -49,33 +61,447 @@ func printWithColor(color, text string) {
 	}
 }
 
-// validateAndRetrieveToken checks if a GitHub token is valid, retrieves user info, scopes, and optionally private repositories
-func validateAndRetrieveToken(token, endpoint string, retrieveInfo, retrieveRepos bool) (*User, []string, []Repository, bool) {
+// DeviceCodeResponse is GitHub's response to a device code request, as described in
+// https://docs.github.com/en/apps/oauth-apps/building-device-flow.
+type DeviceCodeResponse struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// AccessTokenResponse is GitHub's response while polling for a device flow access token.
+type AccessTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	Scope       string `json:"scope"`
+	Error       string `json:"error"`
+}
+
+// deviceFlowLogin drives GitHub's OAuth Device Authorization flow: it requests a device code,
+// shows the user the verification URL and code, then polls for an access token at the
+// server-provided interval until one is issued or the device code expires.
+func deviceFlowLogin(clientID, scopes string) (string, error) {
 	client := &http.Client{}
+
+	form := url.Values{}
+	form.Set("client_id", clientID)
+	if scopes != "" {
+		form.Set("scope", scopes)
+	}
+
+	req, err := http.NewRequest("POST", "https://github.com/login/device/code", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("creating device code request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("requesting device code: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var dc DeviceCodeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&dc); err != nil {
+		return "", fmt.Errorf("decoding device code response: %w", err)
+	}
+
+	fmt.Printf("Go to %s and enter code: %s\n", dc.VerificationURI, dc.UserCode)
+
+	interval := time.Duration(dc.Interval) * time.Second
+	if interval == 0 {
+		interval = 5 * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(dc.ExpiresIn) * time.Second)
+
+	pollForm := url.Values{}
+	pollForm.Set("client_id", clientID)
+	pollForm.Set("device_code", dc.DeviceCode)
+	pollForm.Set("grant_type", "urn:ietf:params:oauth:grant-type:device_code")
+
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+
+		pollReq, err := http.NewRequest("POST", "https://github.com/login/oauth/access_token", strings.NewReader(pollForm.Encode()))
+		if err != nil {
+			return "", fmt.Errorf("creating poll request: %w", err)
+		}
+		pollReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		pollReq.Header.Set("Accept", "application/json")
+
+		pollResp, err := client.Do(pollReq)
+		if err != nil {
+			return "", fmt.Errorf("polling for access token: %w", err)
+		}
+
+		var at AccessTokenResponse
+		decodeErr := json.NewDecoder(pollResp.Body).Decode(&at)
+		pollResp.Body.Close()
+		if decodeErr != nil {
+			return "", fmt.Errorf("decoding access token response: %w", decodeErr)
+		}
+
+		switch at.Error {
+		case "":
+			return at.AccessToken, nil
+		case "authorization_pending":
+			continue
+		case "slow_down":
+			interval += 5 * time.Second
+		case "expired_token":
+			return "", fmt.Errorf("device code expired before authorization")
+		default:
+			return "", fmt.Errorf("device flow error: %s", at.Error)
+		}
+	}
+
+	return "", fmt.Errorf("device flow timed out waiting for authorization")
+}
+
+// classicPATLogin creates a personal access token via the legacy username/password
+// authorizations endpoint, prompting for a 2FA one-time password if GitHub asks for one. This
+// mirrors git-bug's legacy login flow, which is the fallback for users who can't complete the
+// device flow in a browser.
+//
+// GitHub removed POST /authorizations on November 13, 2020; against github.com this always
+// fails with a 404, surfaced through the same "authorization request failed" error as any other
+// non-201 response. It's kept for GitHub Enterprise Server instances still old enough to serve
+// this endpoint, and as the documented historical behavior git-bug's flow was modeled on.
+func classicPATLogin(username, password, note, scopes string) (string, error) {
+	client := &http.Client{}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"scopes": strings.Split(scopes, ","),
+		"note":   note,
+	})
+	if err != nil {
+		return "", fmt.Errorf("encoding authorization request: %w", err)
+	}
+
+	doRequest := func(otp string) (*http.Response, error) {
+		req, err := http.NewRequest("POST", "https://api.github.com/authorizations", strings.NewReader(string(body)))
+		if err != nil {
+			return nil, err
+		}
+		req.SetBasicAuth(username, password)
+		if otp != "" {
+			req.Header.Set("X-GitHub-OTP", otp)
+		}
+		return client.Do(req)
+	}
+
+	resp, err := doRequest("")
+	if err != nil {
+		return "", fmt.Errorf("creating authorization: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized && resp.Header.Get("X-GitHub-OTP") != "" {
+		fmt.Print("Two-factor code: ")
+		reader := bufio.NewReader(os.Stdin)
+		otp, _ := reader.ReadString('\n')
+		otp = strings.TrimSpace(otp)
+
+		resp.Body.Close()
+		resp, err = doRequest(otp)
+		if err != nil {
+			return "", fmt.Errorf("creating authorization with OTP: %w", err)
+		}
+		defer resp.Body.Close()
+	}
+
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("authorization request failed: %s", resp.Status)
+	}
+
+	var auth struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&auth); err != nil {
+		return "", fmt.Errorf("decoding authorization response: %w", err)
+	}
+
+	return auth.Token, nil
+}
+
+// runLogin acquires a new token for the -login flag: it tries the OAuth device flow when a
+// client ID is configured, falls back to the classic username/password prompt otherwise, then
+// validates and optionally persists whatever token it obtains.
+func runLogin(sched *scheduler, clientID, scopes, outFile, endpoint string) {
+	var token string
+	var err error
+
+	if clientID != "" {
+		token, err = deviceFlowLogin(clientID, scopes)
+		if err != nil {
+			fmt.Printf("Device flow failed: %v\n", err)
+		}
+	}
+
+	if token == "" {
+		fmt.Println("Falling back to classic PAT generation (username/password).")
+		fmt.Println("Note: this uses GitHub's legacy /authorizations endpoint, which github.com removed in November 2020 and will 404 against it. It only still works against older GitHub Enterprise Server instances.")
+		reader := bufio.NewReader(os.Stdin)
+
+		fmt.Print("GitHub username: ")
+		username, _ := reader.ReadString('\n')
+		username = strings.TrimSpace(username)
+
+		fmt.Print("GitHub password: ")
+		password, _ := reader.ReadString('\n')
+		password = strings.TrimSpace(password)
+
+		token, err = classicPATLogin(username, password, "ghoa", scopes)
+		if err != nil {
+			fmt.Printf("Error acquiring token: %v\n", err)
+			return
+		}
+	}
+
+	if outFile != "" {
+		f, err := os.OpenFile(outFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			fmt.Printf("Error opening output file: %v\n", err)
+		} else {
+			defer f.Close()
+			fmt.Fprintln(f, token)
+		}
+	}
+
+	user, scopesGranted, _, valid, verr := validateAndRetrieveToken(sched, token, endpoint, true, false)
+	result := Result{Token: token, Valid: valid, User: user, Scopes: scopesGranted}
+	if verr != nil {
+		result.Error = verr.Error()
+	}
+	reportSingleResult(result, "text", true, false, true)
+}
+
+// maxRateLimitRetries caps how many times scheduler.Do retries a single request after a
+// 403/429 response before giving up and returning it to the caller as-is.
+const maxRateLimitRetries = 5
+
+// rateLimitThreshold is how many requests GitHub can have left in the current window before
+// scheduler.Do starts pausing callers until the window resets.
+const rateLimitThreshold = 5
+
+// rateLimiter is a token-bucket limiter shared across goroutines, used to cap requests per
+// second (-rps) regardless of how many workers are running concurrently.
+type rateLimiter struct {
+	ticker *time.Ticker
+	tokens chan struct{}
+}
+
+// newRateLimiter starts a limiter that releases one token every 1/rps seconds.
+func newRateLimiter(rps float64) *rateLimiter {
+	interval := time.Duration(float64(time.Second) / rps)
+	if interval <= 0 {
+		interval = time.Nanosecond
+	}
+
+	rl := &rateLimiter{
+		ticker: time.NewTicker(interval),
+		tokens: make(chan struct{}, 1),
+	}
+	go func() {
+		for range rl.ticker.C {
+			select {
+			case rl.tokens <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return rl
+}
+
+func (rl *rateLimiter) wait() {
+	<-rl.tokens
+}
+
+// backoff computes an exponential retry delay with jitter, starting at 10ms and capping at
+// 10s, similar to the backoff used by other GitHub API clients under sustained rate limiting.
+func backoff(attempt int) time.Duration {
+	const (
+		base = 10 * time.Millisecond
+		max  = 10 * time.Second
+	)
+
+	delay := base * time.Duration(int64(1)<<uint(attempt))
+	if delay <= 0 || delay > max {
+		delay = max
+	}
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// scheduler coordinates HTTP requests against the GitHub API: it throttles via an optional
+// -rps token bucket, pauses every caller when GitHub's own rate limit is nearly exhausted,
+// enforces a per-request -timeout deadline, and retries 403/429 responses with backoff.
+type scheduler struct {
+	client  *http.Client
+	limiter *rateLimiter // nil when -rps is unset
+	timeout time.Duration
+
+	mu         sync.Mutex
+	pauseUntil time.Time
+}
+
+// newScheduler builds a scheduler. rps <= 0 disables the token bucket; timeout <= 0 disables
+// the per-request deadline. caFile, if non-empty, is a PEM-encoded CA bundle trusted in
+// addition to the system roots, for talking to a GitHub Enterprise Server instance with a
+// self-signed certificate.
+func newScheduler(rps float64, timeout time.Duration, caFile string) *scheduler {
+	client := &http.Client{}
+	if caFile != "" {
+		transport, err := transportWithCA(caFile)
+		if err != nil {
+			fmt.Printf("Error loading CA bundle %s: %v\n", caFile, err)
+		} else {
+			client.Transport = transport
+		}
+	}
+
+	s := &scheduler{client: client, timeout: timeout}
+	if rps > 0 {
+		s.limiter = newRateLimiter(rps)
+	}
+	return s
+}
+
+// transportWithCA builds an http.Transport that trusts the PEM-encoded CA bundle at caFile.
+func transportWithCA(caFile string) (*http.Transport, error) {
+	pemData, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA bundle: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemData) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+
+	return &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}, nil
+}
+
+// awaitRateLimitReset blocks until any pause scheduled by recordRateLimit has elapsed.
+func (s *scheduler) awaitRateLimitReset() {
+	s.mu.Lock()
+	until := s.pauseUntil
+	s.mu.Unlock()
+
+	if wait := time.Until(until); wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+// recordRateLimit reads X-RateLimit-Remaining/X-RateLimit-Reset from resp and, once remaining
+// falls to rateLimitThreshold or fewer, schedules a pause until the window resets.
+func (s *scheduler) recordRateLimit(resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil || remaining > rateLimitThreshold {
+		return
+	}
+
+	resetUnix, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.pauseUntil = time.Unix(resetUnix, 0)
+	s.mu.Unlock()
+}
+
+// cancelOnClose wraps a response body so the -timeout deadline it was read under is only
+// canceled once the caller is done with the body, instead of the instant Do returns. Canceling
+// any earlier would race the caller's read and surface as a spurious "context canceled" on
+// anything slower than an instantly-buffered response.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// Do performs req with rate-limit awareness: it waits for the -rps limiter, pauses if
+// GitHub's own rate limit is nearly exhausted, applies the -timeout deadline, and retries
+// 403/429 responses with exponential backoff (honoring Retry-After when present).
+func (s *scheduler) Do(req *http.Request) (*http.Response, error) {
+	if s.limiter != nil {
+		s.limiter.wait()
+	}
+	s.awaitRateLimitReset()
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		var cancel context.CancelFunc
+		if s.timeout > 0 {
+			var ctx context.Context
+			ctx, cancel = context.WithTimeout(req.Context(), s.timeout)
+			attemptReq = req.WithContext(ctx)
+		}
+
+		resp, err := s.client.Do(attemptReq)
+		if err != nil {
+			if cancel != nil {
+				cancel()
+			}
+			return nil, err
+		}
+
+		s.recordRateLimit(resp)
+
+		if (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) && attempt < maxRateLimitRetries {
+			delay := backoff(attempt)
+			if retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				delay = time.Duration(retryAfter) * time.Second
+			}
+			resp.Body.Close()
+			if cancel != nil {
+				cancel()
+			}
+			time.Sleep(delay)
+			continue
+		}
+
+		if cancel != nil {
+			resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+		}
+		return resp, nil
+	}
+}
+
+// validateAndRetrieveToken checks if a GitHub token is valid, retrieves user info, scopes, and
+// optionally private repositories. The returned error is only set for unexpected failures
+// (network, decoding); an invalid token is reported via the bool alone, with no error.
+func validateAndRetrieveToken(sched *scheduler, token, endpoint string, retrieveInfo, retrieveRepos bool) (*User, []string, []Repository, bool, error) {
 	req, err := http.NewRequest("GET", endpoint, nil)
 	if err != nil {
-		fmt.Printf("Error creating request: %v\n", err)
-		return nil, nil, nil, false
+		return nil, nil, nil, false, fmt.Errorf("creating request: %w", err)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	resp, err := client.Do(req)
+	resp, err := sched.Do(req)
 	if err != nil {
-		fmt.Printf("Error making request: %v\n", err)
-		return nil, nil, nil, false
+		return nil, nil, nil, false, fmt.Errorf("making request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, nil, nil, false
+		return nil, nil, nil, false, nil
 	}
 
 	var user User
 	if retrieveInfo {
 		if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
-			fmt.Printf("Error decoding user response: %v\n", err)
-			return nil, nil, nil, false
+			return nil, nil, nil, false, fmt.Errorf("decoding user response: %w", err)
 		}
 	}
 
@@ -92,125 +518,975 @@ func validateAndRetrieveToken(token, endpoint string, retrieveInfo, retrieveRepo
 		reposEndpoint := strings.Replace(endpoint, "/user", "/user/repos?visibility=private", 1)
 		reposReq, err := http.NewRequest("GET", reposEndpoint, nil)
 		if err != nil {
-			fmt.Printf("Error creating repository request: %v\n", err)
-			return &user, scopes, nil, true
+			return &user, scopes, nil, true, fmt.Errorf("creating repository request: %w", err)
 		}
 		reposReq.Header.Set("Authorization", "Bearer "+token)
 
-		reposResp, err := client.Do(reposReq)
+		reposResp, err := sched.Do(reposReq)
 		if err != nil {
-			fmt.Printf("Error retrieving repositories: %v\n", err)
-			return &user, scopes, nil, true
+			return &user, scopes, nil, true, fmt.Errorf("retrieving repositories: %w", err)
 		}
 		defer reposResp.Body.Close()
 
 		if reposResp.StatusCode == http.StatusOK {
 			if err := json.NewDecoder(reposResp.Body).Decode(&repositories); err != nil {
-				fmt.Printf("Error decoding repository response: %v\n", err)
-				return &user, scopes, nil, true
+				return &user, scopes, nil, true, fmt.Errorf("decoding repository response: %w", err)
 			}
 		}
 	}
 
-	return &user, scopes, repositories, true
+	return &user, scopes, repositories, true, nil
 }
 
-// processTokensWithConcurrency validates tokens using multiple goroutines
-func processTokensWithConcurrency(tokens []string, endpoint string, goroutines int, showInfo, retrieveRepos, showInvalid bool) {
-	var wg sync.WaitGroup
-	tokensChan := make(chan string, len(tokens))
+// Result captures everything produced while validating a single token, so it can be rendered
+// either as colored text or, via -output, as structured json/ndjson/csv.
+type Result struct {
+	Token        string                 `json:"token"`
+	Valid        bool                   `json:"valid"`
+	User         *User                  `json:"user,omitempty"`
+	Scopes       []string               `json:"scopes,omitempty"`
+	Repositories []Repository           `json:"repositories,omitempty"`
+	Enumeration  map[string]interface{} `json:"enumeration,omitempty"`
+	Error        string                 `json:"error,omitempty"`
+}
 
-	for _, token := range tokens {
-		tokensChan <- token
+// apiBaseFromEndpoint derives the API base URL (e.g. "https://api.github.com") that
+// enumerators build their own paths from, out of the -e validation endpoint.
+func apiBaseFromEndpoint(endpoint string) string {
+	return strings.TrimSuffix(endpoint, "/user")
+}
+
+// nextPageURL extracts the rel="next" target from a GitHub Link header (RFC 5988), or ""
+// if there is no next page.
+func nextPageURL(linkHeader string) string {
+	for _, part := range strings.Split(linkHeader, ",") {
+		segments := strings.Split(strings.TrimSpace(part), ";")
+		if len(segments) < 2 {
+			continue
+		}
+		if strings.TrimSpace(segments[1]) == `rel="next"` {
+			return strings.Trim(strings.TrimSpace(segments[0]), "<>")
+		}
 	}
-	close(tokensChan)
+	return ""
+}
+
+// paginatedGet GETs firstURL as an authenticated request (through sched, so it shares the
+// same rate-limit awareness as validation requests) and decodes the JSON array response,
+// following rel="next" Link headers until the API reports no further pages.
+func paginatedGet(sched *scheduler, firstURL, token string) ([]json.RawMessage, error) {
+	var all []json.RawMessage
+	next := firstURL
+
+	for next != "" {
+		req, err := http.NewRequest("GET", next, nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating paginated request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := sched.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("performing paginated request: %w", err)
+		}
+
+		var page []json.RawMessage
+		if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("decoding paginated response: %w", err)
+		}
+		next = nextPageURL(resp.Header.Get("Link"))
+		resp.Body.Close()
+
+		all = append(all, page...)
+	}
+
+	return all, nil
+}
+
+// Enumerator performs scope-specific reconnaissance against an already-validated token and
+// returns its findings, so they can be attached to a Result and rendered by any output mode.
+type Enumerator interface {
+	// Scope is the OAuth scope (as reported in X-OAuth-Scopes) that unlocks this enumerator.
+	Scope() string
+	// Name identifies this enumerator's findings within Result.Enumeration.
+	Name() string
+	Enumerate(sched *scheduler, apiBase, token string) (interface{}, error)
+}
+
+// enumerators lists every known Enumerator, keyed by the scope that unlocks it.
+var enumerators = map[string]Enumerator{
+	"repo":             repoEnumerator{},
+	"admin:org":        orgEnumerator{},
+	"read:org":         orgEnumerator{},
+	"gist":             gistEnumerator{},
+	"workflow":         workflowEnumerator{},
+	"admin:public_key": sshKeyEnumerator{},
+	"notifications":    notificationsEnumerator{},
+	"user:email":       emailEnumerator{},
+}
+
+// RepoFindings is a private repository along with its collaborators and deploy key titles.
+type RepoFindings struct {
+	Repository    Repository `json:"repository"`
+	Collaborators []string   `json:"collaborators,omitempty"`
+	DeployKeys    []string   `json:"deploy_keys,omitempty"`
+}
+
+// repoEnumerator covers the "repo" scope: private repositories, their collaborators, and
+// their deploy keys.
+type repoEnumerator struct{}
+
+func (repoEnumerator) Scope() string { return "repo" }
+func (repoEnumerator) Name() string  { return "repositories" }
+
+func (repoEnumerator) Enumerate(sched *scheduler, apiBase, token string) (interface{}, error) {
+	raw, err := paginatedGet(sched, apiBase+"/user/repos?visibility=private&per_page=100", token)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []RepoFindings
+	for _, item := range raw {
+		var repo struct {
+			Repository
+			FullName string `json:"full_name"`
+		}
+		if json.Unmarshal(item, &repo) != nil {
+			continue
+		}
+
+		f := RepoFindings{Repository: repo.Repository}
+
+		if collabs, err := paginatedGet(sched, apiBase+"/repos/"+repo.FullName+"/collaborators", token); err == nil {
+			for _, c := range collabs {
+				var collab struct {
+					Login string `json:"login"`
+				}
+				if json.Unmarshal(c, &collab) == nil {
+					f.Collaborators = append(f.Collaborators, collab.Login)
+				}
+			}
+		}
+
+		if keys, err := paginatedGet(sched, apiBase+"/repos/"+repo.FullName+"/keys", token); err == nil {
+			for _, k := range keys {
+				var key struct {
+					Title string `json:"title"`
+				}
+				if json.Unmarshal(k, &key) == nil {
+					f.DeployKeys = append(f.DeployKeys, key.Title)
+				}
+			}
+		}
+
+		findings = append(findings, f)
+	}
+
+	return findings, nil
+}
+
+// orgFindings is an organization the token's user belongs to, along with its visible members.
+type orgFindings struct {
+	Login   string   `json:"login"`
+	Members []string `json:"members,omitempty"`
+}
+
+// orgEnumerator covers the "admin:org" / "read:org" scopes: org membership and members.
+type orgEnumerator struct{}
+
+func (orgEnumerator) Scope() string { return "admin:org" }
+func (orgEnumerator) Name() string  { return "organizations" }
+
+func (orgEnumerator) Enumerate(sched *scheduler, apiBase, token string) (interface{}, error) {
+	orgs, err := paginatedGet(sched, apiBase+"/user/orgs", token)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []orgFindings
+	for _, item := range orgs {
+		var org struct {
+			Login string `json:"login"`
+		}
+		if json.Unmarshal(item, &org) != nil {
+			continue
+		}
+
+		f := orgFindings{Login: org.Login}
+		if members, err := paginatedGet(sched, apiBase+"/orgs/"+org.Login+"/members", token); err == nil {
+			for _, m := range members {
+				var member struct {
+					Login string `json:"login"`
+				}
+				if json.Unmarshal(m, &member) == nil {
+					f.Members = append(f.Members, member.Login)
+				}
+			}
+		}
+		findings = append(findings, f)
+	}
+
+	return findings, nil
+}
+
+// gistEnumerator covers the "gist" scope: the user's private gists.
+type gistEnumerator struct{}
+
+func (gistEnumerator) Scope() string { return "gist" }
+func (gistEnumerator) Name() string  { return "gists" }
+
+func (gistEnumerator) Enumerate(sched *scheduler, apiBase, token string) (interface{}, error) {
+	gists, err := rawObjects(sched, apiBase+"/gists", token)
+	if err != nil {
+		return nil, err
+	}
+
+	var private []map[string]interface{}
+	for _, gist := range gists {
+		if public, _ := gist["public"].(bool); !public {
+			private = append(private, gist)
+		}
+	}
+	return private, nil
+}
+
+// workflowFindings is a repository's Actions secret names and recent workflow run count.
+type workflowFindings struct {
+	Repository string   `json:"repository"`
+	Secrets    []string `json:"secrets,omitempty"`
+	Runs       int      `json:"recent_runs"`
+}
+
+// workflowEnumerator covers the "workflow" scope: repo secrets and workflow runs.
+type workflowEnumerator struct{}
+
+func (workflowEnumerator) Scope() string { return "workflow" }
+func (workflowEnumerator) Name() string  { return "workflows" }
+
+func (workflowEnumerator) Enumerate(sched *scheduler, apiBase, token string) (interface{}, error) {
+	repos, err := paginatedGet(sched, apiBase+"/user/repos?per_page=100", token)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []workflowFindings
+	for _, item := range repos {
+		var repo struct {
+			FullName string `json:"full_name"`
+		}
+		if json.Unmarshal(item, &repo) != nil {
+			continue
+		}
+
+		f := workflowFindings{Repository: repo.FullName}
+
+		if secretsReq, err := http.NewRequest("GET", apiBase+"/repos/"+repo.FullName+"/actions/secrets", nil); err == nil {
+			secretsReq.Header.Set("Authorization", "Bearer "+token)
+			if resp, err := sched.Do(secretsReq); err == nil {
+				var secrets struct {
+					Secrets []struct {
+						Name string `json:"name"`
+					} `json:"secrets"`
+				}
+				json.NewDecoder(resp.Body).Decode(&secrets)
+				resp.Body.Close()
+				for _, secret := range secrets.Secrets {
+					f.Secrets = append(f.Secrets, secret.Name)
+				}
+			}
+		}
+
+		if runsReq, err := http.NewRequest("GET", apiBase+"/repos/"+repo.FullName+"/actions/runs", nil); err == nil {
+			runsReq.Header.Set("Authorization", "Bearer "+token)
+			if resp, err := sched.Do(runsReq); err == nil {
+				var runs struct {
+					TotalCount int `json:"total_count"`
+				}
+				json.NewDecoder(resp.Body).Decode(&runs)
+				resp.Body.Close()
+				f.Runs = runs.TotalCount
+			}
+		}
+
+		findings = append(findings, f)
+	}
+
+	return findings, nil
+}
+
+// sshKeyEnumerator covers the "admin:public_key" scope: the user's registered SSH keys.
+type sshKeyEnumerator struct{}
+
+func (sshKeyEnumerator) Scope() string { return "admin:public_key" }
+func (sshKeyEnumerator) Name() string  { return "ssh_keys" }
+
+func (sshKeyEnumerator) Enumerate(sched *scheduler, apiBase, token string) (interface{}, error) {
+	return rawObjects(sched, apiBase+"/user/keys", token)
+}
+
+// notificationsEnumerator covers the "notifications" scope: unread notifications.
+type notificationsEnumerator struct{}
+
+func (notificationsEnumerator) Scope() string { return "notifications" }
+func (notificationsEnumerator) Name() string  { return "notifications" }
+
+func (notificationsEnumerator) Enumerate(sched *scheduler, apiBase, token string) (interface{}, error) {
+	return rawObjects(sched, apiBase+"/notifications", token)
+}
+
+// emailEnumerator covers the "user:email" scope: the user's registered email addresses.
+type emailEnumerator struct{}
+
+func (emailEnumerator) Scope() string { return "user:email" }
+func (emailEnumerator) Name() string  { return "emails" }
+
+func (emailEnumerator) Enumerate(sched *scheduler, apiBase, token string) (interface{}, error) {
+	return rawObjects(sched, apiBase+"/user/emails", token)
+}
+
+// rawObjects decodes a paginated JSON array endpoint into generic objects, for enumerators
+// that pass their findings through unchanged rather than re-shaping them.
+func rawObjects(sched *scheduler, endpoint, token string) ([]map[string]interface{}, error) {
+	raw, err := paginatedGet(sched, endpoint, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []map[string]interface{}
+	for _, item := range raw {
+		var obj map[string]interface{}
+		if json.Unmarshal(item, &obj) == nil {
+			objects = append(objects, obj)
+		}
+	}
+	return objects, nil
+}
+
+// runEnumeration runs every Enumerator unlocked by scopes and selected by requested (a
+// comma-separated scope list, or "all"), returning a map of enumerator name to findings.
+func runEnumeration(sched *scheduler, apiBase, token string, scopes []string, requested string) map[string]interface{} {
+	runAll := requested == "all"
+	selected := map[string]bool{}
+	if !runAll {
+		for _, s := range strings.Split(requested, ",") {
+			selected[strings.TrimSpace(s)] = true
+		}
+	}
+
+	results := map[string]interface{}{}
+	done := map[string]bool{}
+	for _, scope := range scopes {
+		enumerator, ok := enumerators[scope]
+		if !ok || done[enumerator.Name()] {
+			continue
+		}
+		if !runAll && !selected[scope] {
+			continue
+		}
+
+		findings, err := enumerator.Enumerate(sched, apiBase, token)
+		if err != nil {
+			results[enumerator.Name()] = map[string]string{"error": err.Error()}
+		} else {
+			results[enumerator.Name()] = findings
+		}
+		done[enumerator.Name()] = true
+	}
+
+	return results
+}
+
+// Identity is a normalized view of "who does this token belong to", since GitHub, GitLab, and
+// Gitea each report user info under different field names.
+type Identity struct {
+	Login string
+	Name  string
+	Email string
+}
+
+// identityToUser adapts an Identity onto the GitHub-shaped User struct Result already carries,
+// so every provider can feed the same rendering and output-mode code.
+func identityToUser(id *Identity) *User {
+	if id == nil {
+		return nil
+	}
+	return &User{Login: id.Login, Name: id.Name, Email: id.Email}
+}
+
+// Provider validates a token against a specific code-hosting API and, where supported,
+// enumerates what it's authorized to see. Concrete implementations exist for github.com and
+// GitHub Enterprise Server, GitLab, and Gitea.
+type Provider interface {
+	// Name identifies the provider for the "provider:token" input file format.
+	Name() string
+	Validate(sched *scheduler, token string) (*Identity, []string, []Repository, bool, error)
+	Enumerate(sched *scheduler, token string, scopes []string, requested string) map[string]interface{}
+}
+
+// knownProviderNames are the -provider values (and "provider:token" input file prefixes)
+// newProvider understands.
+var knownProviderNames = map[string]bool{"github": true, "ghes": true, "gitlab": true, "gitea": true}
+
+// defaultEndpointForProvider returns the conventional validation endpoint for a provider name,
+// used by newProvider when the caller picks a non-GitHub provider without also overriding -e.
+func defaultEndpointForProvider(name string) string {
+	switch name {
+	case "gitlab":
+		return "https://gitlab.com/api/v4/user"
+	case "gitea":
+		return "https://gitea.com/api/v1/user"
+	default:
+		return "https://api.github.com/user"
+	}
+}
+
+// newProvider builds the Provider for name, validated against endpoint. An empty name
+// auto-detects from endpoint's path, falling back to github.com/GHES (the only providers that
+// share that API shape).
+//
+// -e defaults to the github.com endpoint, so selecting -provider gitlab or -provider gitea
+// without also overriding -e used to silently derive an empty (or GitHub-shaped) base URL and
+// send every request to a broken address like "https://api.github.com/user/api/v4/user". If
+// endpoint is still that default, newProvider substitutes the chosen provider's own
+// conventional endpoint instead; if endpoint was overridden to something that still doesn't
+// match the requested provider, it fails loudly rather than building a broken URL.
+func newProvider(name, endpoint string, retrieveInfo, retrieveRepos bool) (Provider, error) {
+	switch name {
+	case "gitlab":
+		if endpoint == defaultEndpointForProvider("github") {
+			endpoint = defaultEndpointForProvider("gitlab")
+		}
+		if !strings.Contains(endpoint, "/api/v4/") {
+			return nil, fmt.Errorf("-provider gitlab requires a GitLab -e endpoint (containing /api/v4/), got %q", endpoint)
+		}
+		return gitlabProvider{base: strings.TrimSuffix(endpoint, "/api/v4/user")}, nil
+	case "gitea":
+		if endpoint == defaultEndpointForProvider("github") {
+			endpoint = defaultEndpointForProvider("gitea")
+		}
+		if !strings.Contains(endpoint, "/api/v1/") {
+			return nil, fmt.Errorf("-provider gitea requires a Gitea -e endpoint (containing /api/v1/), got %q", endpoint)
+		}
+		return giteaProvider{base: strings.TrimSuffix(endpoint, "/api/v1/user")}, nil
+	case "github", "ghes":
+		return githubProvider{endpoint: endpoint, retrieveInfo: retrieveInfo, retrieveRepos: retrieveRepos}, nil
+	}
+
+	switch {
+	case strings.Contains(endpoint, "/api/v4/"):
+		return gitlabProvider{base: strings.TrimSuffix(endpoint, "/api/v4/user")}, nil
+	case strings.Contains(endpoint, "/api/v1/"):
+		return giteaProvider{base: strings.TrimSuffix(endpoint, "/api/v1/user")}, nil
+	default:
+		return githubProvider{endpoint: endpoint, retrieveInfo: retrieveInfo, retrieveRepos: retrieveRepos}, nil
+	}
+}
+
+// githubProvider validates github.com and GitHub Enterprise Server tokens, which share the
+// same /user endpoint and X-OAuth-Scopes header shape and differ only in base URL.
+type githubProvider struct {
+	endpoint      string
+	retrieveInfo  bool
+	retrieveRepos bool
+}
+
+func (p githubProvider) Name() string { return "github" }
+
+func (p githubProvider) Validate(sched *scheduler, token string) (*Identity, []string, []Repository, bool, error) {
+	user, scopes, repos, valid, err := validateAndRetrieveToken(sched, token, p.endpoint, p.retrieveInfo, p.retrieveRepos)
+	if user == nil {
+		return nil, scopes, repos, valid, err
+	}
+	return &Identity{Login: user.Login, Name: user.Name, Email: user.Email}, scopes, repos, valid, err
+}
+
+func (p githubProvider) Enumerate(sched *scheduler, token string, scopes []string, requested string) map[string]interface{} {
+	if requested == "" {
+		return nil
+	}
+	return runEnumeration(sched, apiBaseFromEndpoint(p.endpoint), token, scopes, requested)
+}
+
+// gitlabProvider validates tokens against a GitLab instance's /api/v4/user endpoint, using
+// the PRIVATE-TOKEN header instead of an OAuth Bearer token, and reports scopes via
+// /oauth/token/info. Enumeration isn't implemented for GitLab yet.
+type gitlabProvider struct {
+	base string
+}
+
+func (p gitlabProvider) Name() string { return "gitlab" }
+
+func (p gitlabProvider) Validate(sched *scheduler, token string) (*Identity, []string, []Repository, bool, error) {
+	req, err := http.NewRequest("GET", p.base+"/api/v4/user", nil)
+	if err != nil {
+		return nil, nil, nil, false, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("PRIVATE-TOKEN", token)
+
+	resp, err := sched.Do(req)
+	if err != nil {
+		return nil, nil, nil, false, fmt.Errorf("making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, nil, false, nil
+	}
+
+	var user struct {
+		Username string `json:"username"`
+		Name     string `json:"name"`
+		Email    string `json:"email"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, nil, nil, false, fmt.Errorf("decoding user response: %w", err)
+	}
+
+	return &Identity{Login: user.Username, Name: user.Name, Email: user.Email}, p.scopes(sched, token), nil, true, nil
+}
+
+// scopes reports the scopes a GitLab token was granted via /oauth/token/info, returning nil
+// if the lookup fails (e.g. the token is a legacy PAT rather than an OAuth token).
+func (p gitlabProvider) scopes(sched *scheduler, token string) []string {
+	req, err := http.NewRequest("GET", p.base+"/oauth/token/info", nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := sched.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	var info struct {
+		Scope []string `json:"scope"`
+	}
+	if json.NewDecoder(resp.Body).Decode(&info) != nil {
+		return nil
+	}
+	return info.Scope
+}
+
+func (gitlabProvider) Enumerate(sched *scheduler, token string, scopes []string, requested string) map[string]interface{} {
+	return nil
+}
+
+// giteaProvider validates tokens against a Gitea instance's /api/v1/user endpoint. Gitea
+// doesn't report granted scopes on validation, and enumeration isn't implemented yet.
+type giteaProvider struct {
+	base string
+}
+
+func (p giteaProvider) Name() string { return "gitea" }
+
+func (p giteaProvider) Validate(sched *scheduler, token string) (*Identity, []string, []Repository, bool, error) {
+	req, err := http.NewRequest("GET", p.base+"/api/v1/user", nil)
+	if err != nil {
+		return nil, nil, nil, false, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "token "+token)
+
+	resp, err := sched.Do(req)
+	if err != nil {
+		return nil, nil, nil, false, fmt.Errorf("making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, nil, false, nil
+	}
+
+	var user struct {
+		Login string `json:"login"`
+		Name  string `json:"full_name"`
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, nil, nil, false, fmt.Errorf("decoding user response: %w", err)
+	}
+
+	return &Identity{Login: user.Login, Name: user.Name, Email: user.Email}, nil, nil, true, nil
+}
+
+func (giteaProvider) Enumerate(sched *scheduler, token string, scopes []string, requested string) map[string]interface{} {
+	return nil
+}
+
+// tokenEntry pairs a token with the provider name parsed from its input line, "" meaning the
+// run's default provider (explicit -provider, or auto-detected from -e) applies.
+type tokenEntry struct {
+	provider string
+	token    string
+}
+
+// parseTokenLine splits a "provider:token" input line into its provider name and the token
+// itself. Lines without a recognized provider prefix are treated as bare tokens.
+func parseTokenLine(line string) tokenEntry {
+	if idx := strings.Index(line, ":"); idx != -1 {
+		if candidate := line[:idx]; knownProviderNames[candidate] {
+			return tokenEntry{provider: candidate, token: line[idx+1:]}
+		}
+	}
+	return tokenEntry{token: line}
+}
+
+// providerFor resolves the Provider for a single token entry: a per-line prefix takes
+// priority over the run's default provider.
+func providerFor(entry tokenEntry, defaultProvider Provider, endpoint string, retrieveInfo, retrieveRepos bool) (Provider, error) {
+	if entry.provider == "" {
+		return defaultProvider, nil
+	}
+	return newProvider(entry.provider, endpoint, retrieveInfo, retrieveRepos)
+}
+
+// bloomFilter is a streaming Bloom filter used to de-duplicate tokens across concatenated
+// dumps without holding every token seen so far in memory. It is sized once up front for an
+// expected item count and false-positive rate, and is only ever used by the single goroutine
+// streaming tokens off disk, so it needs no locking.
+type bloomFilter struct {
+	bits []uint64
+	m    uint64 // number of bits
+	k    int    // number of hash functions
+}
+
+// newBloomFilter sizes a filter for n expected items at the given false-positive rate, using
+// the standard optimal-m/optimal-k formulas.
+func newBloomFilter(n int, fpRate float64) *bloomFilter {
+	if n <= 0 {
+		n = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+
+	m := uint64(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	if m == 0 {
+		m = 1
+	}
+	k := int(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+
+	return &bloomFilter{bits: make([]uint64, m/64+1), m: m, k: k}
+}
+
+// hashes returns two independent hashes of item, combined via double hashing to simulate k
+// hash functions without running k real ones.
+func (bf *bloomFilter) hashes(item string) (uint64, uint64) {
+	h1 := fnv.New64a()
+	h1.Write([]byte(item))
+
+	h2 := fnv.New64()
+	h2.Write([]byte(item))
+
+	return h1.Sum64(), h2.Sum64()
+}
+
+// addIfAbsent marks item as seen and reports whether it was (possibly, given the false-positive
+// rate) already present.
+func (bf *bloomFilter) addIfAbsent(item string) bool {
+	h1, h2 := bf.hashes(item)
+
+	alreadyPresent := true
+	for i := 0; i < bf.k; i++ {
+		bit := (h1 + uint64(i)*h2) % bf.m
+		if bf.bits[bit/64]&(1<<(bit%64)) == 0 {
+			alreadyPresent = false
+			bf.bits[bit/64] |= 1 << (bit % 64)
+		}
+	}
+	return alreadyPresent
+}
+
+// gzipReadCloser pairs a gzip.Reader with the underlying file it decompresses, so closing it
+// releases both.
+type gzipReadCloser struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g gzipReadCloser) Close() error {
+	g.Reader.Close()
+	return g.file.Close()
+}
+
+// openTokenSource opens path for streaming, transparently decompressing based on its
+// extension. .gz is handled via the standard library. .zst is recognized by extension but
+// deliberately unimplemented: ghoa ships as a single file with no dependency manager, so there
+// is nowhere to vendor a zstd decoder from. This is a known, permanent gap rather than a
+// TODO — callers get a clear error instead of a silent pass-through of compressed bytes.
+func openTokenSource(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case strings.HasSuffix(path, ".gz"):
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("opening gzip stream: %w", err)
+		}
+		return gzipReadCloser{Reader: gz, file: file}, nil
+	case strings.HasSuffix(path, ".zst"):
+		file.Close()
+		return nil, fmt.Errorf("%s: zstd input is not supported (no vendored zstd decoder); decompress to plain text or .gz first", path)
+	default:
+		return file, nil
+	}
+}
+
+// streamTokenEntries reads tokens from src line by line and feeds them into entriesChan,
+// skipping duplicates via bloom (if non-nil), so memory use stays constant regardless of
+// input file size. It closes entriesChan once src is exhausted or on the first read error.
+func streamTokenEntries(src io.Reader, entriesChan chan<- tokenEntry, bloom *bloomFilter) error {
+	defer close(entriesChan)
+
+	scanner := bufio.NewScanner(src)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		entry := parseTokenLine(line)
+		if bloom != nil && bloom.addIfAbsent(entry.token) {
+			continue
+		}
+
+		entriesChan <- entry
+	}
+
+	return scanner.Err()
+}
+
+// printTextResult renders a single Result the way ghoa has always printed to the terminal:
+// a colored one-line status followed by optional detail blocks.
+func printTextResult(r Result, showInfo, retrieveRepos, showInvalid bool) {
+	if r.Valid {
+		printWithColor(green, fmt.Sprintf("Valid token: %s", r.Token))
+		if showInfo && r.User != nil {
+			fmt.Printf("User Info: %+v\n", *r.User)
+			fmt.Printf("Scopes: %v\n", r.Scopes)
+		}
+		if retrieveRepos {
+			fmt.Println("Private Repositories:")
+			for _, repo := range r.Repositories {
+				fmt.Printf("- %s (%s)\n", repo.Name, repo.URL)
+			}
+		}
+	} else if showInvalid {
+		printWithColor(red, fmt.Sprintf("Invalid token: %s", r.Token))
+	}
+	for name, findings := range r.Enumeration {
+		pretty, _ := json.MarshalIndent(findings, "  ", "  ")
+		fmt.Printf("%s:\n  %s\n", name, pretty)
+	}
+	if r.Error != "" {
+		fmt.Printf("Error: %s\n", r.Error)
+	}
+}
+
+// repoNames flattens Repositories into "name (url)" strings for the flat CSV output mode.
+func repoNames(repos []Repository) []string {
+	names := make([]string, len(repos))
+	for i, repo := range repos {
+		names[i] = fmt.Sprintf("%s (%s)", repo.Name, repo.URL)
+	}
+	return names
+}
+
+// loginOf returns u.Login, or "" if u is nil.
+func loginOf(u *User) string {
+	if u == nil {
+		return ""
+	}
+	return u.Login
+}
+
+// reportResults consumes validation results from resultsChan and renders them according to
+// outputMode, so that workers never touch stdout directly. It runs on its own goroutine and
+// closes done when resultsChan is drained.
+func reportResults(resultsChan <-chan Result, outputMode string, showInfo, retrieveRepos, showInvalid bool, done chan<- struct{}) {
+	defer close(done)
+
+	switch outputMode {
+	case "json":
+		var results []Result
+		for r := range resultsChan {
+			results = append(results, r)
+		}
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			fmt.Printf("Error encoding JSON output: %v\n", err)
+			return
+		}
+		fmt.Println(string(data))
+
+	case "ndjson":
+		encoder := json.NewEncoder(os.Stdout)
+		for r := range resultsChan {
+			if err := encoder.Encode(r); err != nil {
+				fmt.Printf("Error encoding NDJSON result: %v\n", err)
+			}
+		}
+
+	case "csv":
+		writer := csv.NewWriter(os.Stdout)
+		writer.Write([]string{"token", "valid", "login", "scopes", "repositories", "error"})
+		for r := range resultsChan {
+			writer.Write([]string{
+				r.Token,
+				fmt.Sprintf("%t", r.Valid),
+				loginOf(r.User),
+				strings.Join(r.Scopes, ";"),
+				strings.Join(repoNames(r.Repositories), ";"),
+				r.Error,
+			})
+		}
+		writer.Flush()
+
+	default: // "text"
+		for r := range resultsChan {
+			printTextResult(r, showInfo, retrieveRepos, showInvalid)
+		}
+	}
+}
+
+// reportSingleResult renders one Result for the -k single-token path through the same
+// outputMode rendering processTokensWithConcurrency uses, so both paths stay in sync.
+func reportSingleResult(r Result, outputMode string, showInfo, retrieveRepos, showInvalid bool) {
+	resultsChan := make(chan Result, 1)
+	done := make(chan struct{})
+	go reportResults(resultsChan, outputMode, showInfo, retrieveRepos, showInvalid, done)
+	resultsChan <- r
+	close(resultsChan)
+	<-done
+}
+
+// processTokensWithConcurrency validates tokens using multiple goroutines, feeding each
+// result into a dedicated reporter goroutine rather than printing from the workers themselves.
+// All workers share sched, so -rps and GitHub's rate-limit headers are honored across the
+// whole run rather than per-goroutine. Each entry is validated through the Provider its
+// "provider:token" prefix selects, or defaultProvider when it has none. entriesChan is
+// expected to be fed by streamTokenEntries concurrently, so memory use stays bounded
+// regardless of how many tokens the input file holds.
+func processTokensWithConcurrency(entriesChan <-chan tokenEntry, endpoint string, goroutines int, showInfo, retrieveRepos, showInvalid bool, outputMode, enumFlag string, sched *scheduler, defaultProvider Provider) {
+	var wg sync.WaitGroup
+	resultsChan := make(chan Result, goroutines)
+	done := make(chan struct{})
+
+	go reportResults(resultsChan, outputMode, showInfo, retrieveRepos, showInvalid, done)
 
 	for i := 0; i < goroutines; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for token := range tokensChan {
-				user, scopes, repos, valid := validateAndRetrieveToken(token, endpoint, showInfo, retrieveRepos)
-				if valid {
-					printWithColor(green, fmt.Sprintf("Valid token: %s", token))
-					if showInfo && user != nil {
-						fmt.Printf("User Info: %+v\n", *user)
-						fmt.Printf("Scopes: %v\n", scopes)
-					}
-					if retrieveRepos {
-						fmt.Println("Private Repositories:")
-						for _, repo := range repos {
-							fmt.Printf("- %s (%s)\n", repo.Name, repo.URL)
-						}
-					}
-				} else {
-					if showInvalid {
-						printWithColor(red, fmt.Sprintf("Invalid token: %s", token))
-					}
+			for entry := range entriesChan {
+				provider, err := providerFor(entry, defaultProvider, endpoint, showInfo, retrieveRepos)
+				if err != nil {
+					resultsChan <- Result{Token: entry.token, Error: err.Error()}
+					continue
+				}
+				identity, scopes, repos, valid, err := provider.Validate(sched, entry.token)
+				result := Result{Token: entry.token, Valid: valid, User: identityToUser(identity), Scopes: scopes, Repositories: repos}
+				if err != nil {
+					result.Error = err.Error()
 				}
+				if valid && enumFlag != "" {
+					result.Enumeration = provider.Enumerate(sched, entry.token, scopes, enumFlag)
+				}
+				resultsChan <- result
 			}
 		}()
 	}
 
 	wg.Wait()
+	close(resultsChan)
+	<-done
 }
 
 func main() {
 	// Command-line flags
 	keyFlag := flag.String("k", "", "GitHub OAuth2 token to validate")
-	fileFlag := flag.String("f", "", "File containing tokens (one per line)")
+	fileFlag := flag.String("f", "", "File containing tokens (one per line); .gz is decompressed automatically, .zst is detected but not supported")
 	numGoroutines := flag.Int("t", 1, "Number of goroutines to use")
 	showInvalid := flag.Bool("d", false, "Show invalid tokens")
 	removeColorFlag := flag.Bool("remove-color", false, "Remove color from output")
 	showInfo := flag.Bool("i", false, "Retrieve and display detailed information and scopes about valid tokens")
 	retrieveRepos := flag.Bool("p", false, "Retrieve and display private repositories for valid tokens")
 	endpointFlag := flag.String("e", "https://api.github.com/user", "GitHub API endpoint to use for validation")
+	loginFlag := flag.Bool("login", false, "Acquire a new token via GitHub's OAuth device flow")
+	clientIDFlag := flag.String("client-id", "", "OAuth App client ID to use with -login")
+	scopesFlag := flag.String("scopes", "repo", "Comma-separated scopes to request with -login")
+	outFlag := flag.String("o", "", "File to append the token acquired with -login to")
+	outputFlag := flag.String("output", "text", "Output format: text, json, ndjson, or csv")
+	enumFlag := flag.String("enum", "", "Comma-separated scopes to enumerate for valid tokens, or \"all\"")
+	rpsFlag := flag.Float64("rps", 0, "Global requests-per-second cap shared across all goroutines (0 = unlimited)")
+	timeoutFlag := flag.Duration("timeout", 30*time.Second, "Per-request timeout")
+	providerFlag := flag.String("provider", "", "Provider to validate against: github, ghes, gitlab, or gitea (default: auto-detect from -e)")
+	caFlag := flag.String("ca", "", "PEM-encoded CA bundle to trust, for a GitHub Enterprise Server instance with a self-signed certificate")
+	dedupFlag := flag.Bool("dedup", false, "Skip tokens already seen in this run via a streaming Bloom filter")
+	expectedFlag := flag.Int("expected-tokens", 1_000_000, "Expected token count, used to size the -dedup Bloom filter")
+	fpRateFlag := flag.Float64("fp-rate", 0.01, "False-positive rate for the -dedup Bloom filter")
 
 	flag.Parse()
 
 	removeColor = *removeColorFlag
+	sched := newScheduler(*rpsFlag, *timeoutFlag, *caFlag)
+	defaultProvider, err := newProvider(*providerFlag, *endpointFlag, *showInfo, *retrieveRepos)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *loginFlag {
+		runLogin(sched, *clientIDFlag, *scopesFlag, *outFlag, *endpointFlag)
+		return
+	}
 
 	if *keyFlag != "" {
 		// Validate a single token
-		user, scopes, repos, valid := validateAndRetrieveToken(*keyFlag, *endpointFlag, *showInfo, *retrieveRepos)
-		if valid {
-			printWithColor(green, fmt.Sprintf("Valid token: %s", *keyFlag))
-			if *showInfo && user != nil {
-				fmt.Printf("User Info: %+v\n", *user)
-				fmt.Printf("Scopes: %v\n", scopes)
-			}
-			if *retrieveRepos {
-				fmt.Println("Private Repositories:")
-				for _, repo := range repos {
-					fmt.Printf("- %s (%s)\n", repo.Name, repo.URL)
-				}
-			}
-		} else {
-			if *showInvalid {
-				printWithColor(red, fmt.Sprintf("Invalid token: %s", *keyFlag))
-			}
+		identity, scopes, repos, valid, err := defaultProvider.Validate(sched, *keyFlag)
+		result := Result{Token: *keyFlag, Valid: valid, User: identityToUser(identity), Scopes: scopes, Repositories: repos}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		if valid && *enumFlag != "" {
+			result.Enumeration = defaultProvider.Enumerate(sched, *keyFlag, scopes, *enumFlag)
 		}
+		reportSingleResult(result, *outputFlag, *showInfo, *retrieveRepos, *showInvalid)
 	} else if *fileFlag != "" {
-		// Validate tokens from a file
-		file, err := os.Open(*fileFlag)
+		// Stream tokens from a file (transparently decompressing .gz) instead of loading it
+		// all into memory; each line may be a bare token or "provider:token". Reading and
+		// validation run concurrently, so constant memory use holds regardless of file size.
+		src, err := openTokenSource(*fileFlag)
 		if err != nil {
 			fmt.Printf("Error opening file: %v\n", err)
 			return
 		}
-		defer file.Close()
+		defer src.Close()
 
-		scanner := bufio.NewScanner(file)
-		var tokens []string
-		for scanner.Scan() {
-			tokens = append(tokens, scanner.Text())
+		var bloom *bloomFilter
+		if *dedupFlag {
+			bloom = newBloomFilter(*expectedFlag, *fpRateFlag)
 		}
 
-		if err := scanner.Err(); err != nil {
-			fmt.Printf("Error reading file: %v\n", err)
-			return
-		}
+		entriesChan := make(chan tokenEntry, 4*(*numGoroutines))
+		go func() {
+			if err := streamTokenEntries(src, entriesChan, bloom); err != nil {
+				fmt.Printf("Error reading file: %v\n", err)
+			}
+		}()
 
-		processTokensWithConcurrency(tokens, *endpointFlag, *numGoroutines, *showInfo, *retrieveRepos, *showInvalid)
+		processTokensWithConcurrency(entriesChan, *endpointFlag, *numGoroutines, *showInfo, *retrieveRepos, *showInvalid, *outputFlag, *enumFlag, sched, defaultProvider)
 	} else {
 		fmt.Println("Please specify a token (-k) or a file (-f) containing tokens.")
 		flag.Usage()